@@ -0,0 +1,206 @@
+// Package jwks verifies Cognito-issued JWTs locally against the user
+// pool's JSON Web Key Set, so callers don't need to round-trip to
+// Cognito on every request.
+package jwks
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultTTL is how long a fetched key set is trusted before it is
+// considered stale and eligible for a background refresh.
+const DefaultTTL = 1 * time.Hour
+
+// Claims holds the subset of a Cognito id/access token's claims that
+// callers typically need after verification.
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenUse string `json:"token_use"`
+	ClientID string `json:"client_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Verifier lazily fetches and caches a Cognito user pool's JWKS and
+// verifies tokens against it. It is safe for concurrent use.
+type Verifier struct {
+	issuer     string
+	clientID   string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu         sync.RWMutex
+	keys       map[string]*rsa.PublicKey
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// New returns a Verifier for the given Cognito region and user pool,
+// scoped to tokens issued for clientID (the app client's aud/client_id).
+func New(region, userPoolID, clientID string) *Verifier {
+	return &Verifier{
+		issuer:     fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolID),
+		clientID:   clientID,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		ttl:        DefaultTTL,
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// Verify checks the RS256 signature on token against the cached JWKS and
+// validates iss, aud/client_id, token_use, and the exp/nbf/iat claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != "RS256" {
+			return nil, fmt.Errorf("unexpected signing method: %s", t.Method.Alg())
+		}
+		kid, ok := t.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, errors.New("token is missing kid header")
+		}
+		return v.key(ctx, kid)
+	}, jwt.WithIssuer(v.issuer), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	if claims.TokenUse != "id" && claims.TokenUse != "access" {
+		return nil, fmt.Errorf("unexpected token_use: %q", claims.TokenUse)
+	}
+
+	aud := claims.ClientID
+	if aud == "" && len(claims.Audience) > 0 {
+		aud = claims.Audience[0]
+	}
+	if aud != v.clientID {
+		return nil, fmt.Errorf("token is not intended for this client")
+	}
+
+	return claims, nil
+}
+
+// key returns the RSA public key for kid, fetching (or refreshing) the
+// JWKS document on a cache miss or once it has gone stale.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.ttl
+	v.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if ok && stale {
+		// Key is already known; refresh in the background in case it was
+		// rotated, but don't block this request on it.
+		v.refreshAsync()
+		return key, nil
+	}
+
+	// Unknown kid: the pool may have rotated its signing keys, so this
+	// request waits for a synchronous refresh before failing.
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	return key, nil
+}
+
+func (v *Verifier) refreshAsync() {
+	v.mu.Lock()
+	if v.refreshing {
+		v.mu.Unlock()
+		return
+	}
+	v.refreshing = true
+	v.mu.Unlock()
+
+	go func() {
+		defer func() {
+			v.mu.Lock()
+			v.refreshing = false
+			v.mu.Unlock()
+		}()
+		_ = v.refresh(context.Background())
+	}()
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	url := v.issuer + "/.well-known/jwks.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var doc jwksDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pubKey, err := parseRSAPublicKey(k.N, k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pubKey
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
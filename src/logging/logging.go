@@ -0,0 +1,110 @@
+// Package logging wraps slog with request-scoped fields and redaction of
+// sensitive attributes, so handlers can log freely without leaking
+// credentials into CloudWatch.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// WithFields returns a context carrying attrs that Handler will attach to
+// every log record emitted for the lifetime of that context, merging with
+// any fields already attached by an outer call.
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if existing, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		attrs = append(append([]slog.Attr{}, existing...), attrs...)
+	}
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+func fieldsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// sensitiveKeys are attribute keys whose values are always redacted,
+// regardless of content.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret_hash":   true,
+	"token":         true,
+	"refresh_token": true,
+	"authorization": true,
+}
+
+const redacted = "[REDACTED]"
+
+// Handler wraps another slog.Handler to inject request-scoped fields
+// (via context, see WithFields), redact sensitive attributes, and
+// optionally drop a fraction of INFO records to control log volume.
+// WARN and ERROR records are never sampled away.
+type Handler struct {
+	next       slog.Handler
+	sampleRate float64 // fraction of INFO records to drop, 0..1
+}
+
+// NewHandler wraps next. sampleRate is the fraction of INFO records to
+// drop (e.g. 0.9 keeps 1 in 10); values outside [0, 1] are clamped.
+func NewHandler(next slog.Handler, sampleRate float64) *Handler {
+	if sampleRate < 0 {
+		sampleRate = 0
+	}
+	if sampleRate > 1 {
+		sampleRate = 1
+	}
+	return &Handler{next: next, sampleRate: sampleRate}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && h.sampleRate > 0 && rand.Float64() < h.sampleRate {
+		return nil
+	}
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	out.AddAttrs(fieldsFromContext(ctx)...)
+
+	return h.next.Handle(ctx, out)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &Handler{next: h.next.WithAttrs(redacted), sampleRate: h.sampleRate}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), sampleRate: h.sampleRate}
+}
+
+// redactAttr replaces a.Value with a fixed placeholder when its key is a
+// known-sensitive field or its value looks like a JWT.
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString && looksLikeJWT(a.Value.String()) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// looksLikeJWT reports whether v has the three dot-separated segments of
+// a compact JWT, starting with the standard base64url "eyJ" header prefix.
+func looksLikeJWT(v string) bool {
+	return strings.HasPrefix(v, "eyJ") && strings.Count(v, ".") == 2
+}
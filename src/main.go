@@ -2,30 +2,36 @@ package main
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
-	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/logging"
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/provider"
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/provider/cognito"
 )
 
 type appConfig struct {
-	clientID      string
-	clientSecret  string
-	userPoolID    string
-	region        string
-	returnJSONObj bool // when true, body is a native map (for local testing)
+	clientID       string
+	clientSecret   string
+	userPoolID     string
+	region         string
+	authProvider   string  // which AuthProvider backend to construct (default "cognito")
+	returnJSONObj  bool    // when true, body is a native map (for local testing)
+	authorizerMode bool    // when true, run as an APIGW REQUEST authorizer instead of the auth API
+	runMode        string  // "lambda" or "http"
+	port           string  // listen port when runMode is "http"
+	logSampling    float64 // fraction of INFO logs to drop, 0..1
 }
 
 func loadConfig() (appConfig, error) {
@@ -39,24 +45,96 @@ func loadConfig() (appConfig, error) {
 		returnJSONObj = true
 	}
 
+	authorizerMode := false
+	if v := strings.ToLower(os.Getenv("AUTHORIZER_MODE")); v == "1" || v == "true" || v == "yes" {
+		authorizerMode = true
+	}
+
+	authProvider := os.Getenv("AUTH_PROVIDER")
+	if authProvider == "" {
+		authProvider = "cognito"
+	}
+
+	runMode := strings.ToLower(os.Getenv("RUN_MODE"))
+	if runMode == "" {
+		if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") == "" {
+			runMode = "http"
+		} else {
+			runMode = "lambda"
+		}
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	logSampling := 0.0
+	if v := os.Getenv("LOG_SAMPLING"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return appConfig{}, fmt.Errorf("invalid LOG_SAMPLING: %w", err)
+		}
+		logSampling = parsed
+	}
+
 	return appConfig{
-		clientID:      clientID,
-		clientSecret:  os.Getenv("COGNITO_CLIENT_SECRET"),
-		userPoolID:    os.Getenv("COGNITO_USER_POOL_ID"),
-		region:        os.Getenv("AWS_REGION"),
-		returnJSONObj: returnJSONObj,
+		clientID:       clientID,
+		clientSecret:   os.Getenv("COGNITO_CLIENT_SECRET"),
+		userPoolID:     os.Getenv("COGNITO_USER_POOL_ID"),
+		region:         os.Getenv("AWS_REGION"),
+		authProvider:   authProvider,
+		returnJSONObj:  returnJSONObj,
+		authorizerMode: authorizerMode,
+		runMode:        runMode,
+		port:           port,
+		logSampling:    logSampling,
 	}, nil
 }
 
+// newProvider constructs the AuthProvider backend named by cfg.authProvider.
+func newProvider(ctx context.Context, cfg appConfig) (provider.AuthProvider, error) {
+	switch cfg.authProvider {
+	case "cognito":
+		return cognito.New(ctx, cognito.Config{
+			ClientID:     cfg.clientID,
+			ClientSecret: cfg.clientSecret,
+			UserPoolID:   cfg.userPoolID,
+			Region:       cfg.region,
+		})
+	default:
+		return nil, fmt.Errorf("unknown AUTH_PROVIDER: %q", cfg.authProvider)
+	}
+}
+
 type authRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email         string            `json:"email"`
+	Password      string            `json:"password"`
+	GrantType     string            `json:"grant_type"`
+	RefreshToken  string            `json:"refresh_token"`
+	ChallengeName string            `json:"challenge_name"`
+	Session       string            `json:"session"`
+	Responses     map[string]string `json:"responses"`
+	Name          string            `json:"name"`
+	PhoneNumber   string            `json:"phone_number"`
+	Code          string            `json:"code"`
+	NewPassword   string            `json:"new_password"`
+}
+
+type signUpResponse struct {
+	UserSub       string `json:"user_sub"`
+	UserConfirmed bool   `json:"user_confirmed"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
 }
 
 type authResponse struct {
-	Token     *string `json:"token"`
-	ExpiresIn *int32  `json:"expires_in"`
-	TokenType *string `json:"token_type"`
+	Token        *string `json:"token"`
+	RefreshToken *string `json:"refresh_token,omitempty"`
+	ExpiresIn    *int32  `json:"expires_in"`
+	TokenType    *string `json:"token_type"`
 }
 
 type errorResponse struct {
@@ -68,140 +146,344 @@ type challengeResponse struct {
 	Error     string  `json:"error"`
 	Message   string  `json:"message"`
 	Challenge *string `json:"challenge"`
+	Session   *string `json:"session,omitempty"`
 }
 
-type handler struct {
-	cfg     appConfig
-	logger  *slog.Logger
-	cognito *cognitoidentityprovider.Client
+type verifyRequest struct {
+	Token string `json:"token"`
 }
 
-func newHandler(ctx context.Context, cfg appConfig) (*handler, error) {
-	opts := []func(*config.LoadOptions) error{}
-	if cfg.region != "" {
-		opts = append(opts, config.WithRegion(cfg.region))
-	}
+type verifyResponse struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenUse  string `json:"token_use"`
+	ExpiresAt int64  `json:"exp"`
+}
 
-	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
-	if err != nil {
-		return nil, err
-	}
+// request is the transport-agnostic view of an inbound call that the
+// core handler logic operates on, populated by the lambda and http
+// adapters from their own SDK-specific request types.
+type request struct {
+	Path string
+	Body string
+}
+
+// response is the transport-agnostic result of handling a request; each
+// adapter translates it into its own SDK-specific response type.
+type response struct {
+	StatusCode int
+	Body       string
+}
+
+type handler struct {
+	cfg    appConfig
+	logger *slog.Logger
+	auth   provider.AuthProvider
+}
 
+func newHandler(ctx context.Context, cfg appConfig) (*handler, error) {
 	logLevel := slog.LevelInfo
 	if strings.ToUpper(os.Getenv("LOG_LEVEL")) == "DEBUG" {
 		logLevel = slog.LevelDebug
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	jsonHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})
+	logger := slog.New(logging.NewHandler(jsonHandler, cfg.logSampling))
+
+	auth, err := newProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &handler{
-		cfg:     cfg,
-		logger:  logger,
-		cognito: cognitoidentityprovider.NewFromConfig(awsCfg),
+		cfg:    cfg,
+		logger: logger,
+		auth:   auth,
 	}, nil
 }
 
-func (h *handler) Handle(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-	h.logger.InfoContext(ctx, "handle_auth invoked")
+// handle contains the core auth API logic, independent of whether it was
+// reached via API Gateway or a plain net/http listener.
+func (h *handler) handle(ctx context.Context, r request) response {
+	h.logger.InfoContext(ctx, "handle_auth invoked", "path", r.Path)
 
-	rawBody := event.Body
-	if rawBody == "" {
-		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Request body is required."), nil
+	if r.Body == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Request body is required.")
 	}
 
-	if event.IsBase64Encoded {
-		decoded, err := base64.StdEncoding.DecodeString(rawBody)
-		if err != nil {
-			return h.errorResponse(http.StatusBadRequest, "invalid_request", "Invalid base64 body"), nil
-		}
-		rawBody = string(decoded)
+	if strings.HasSuffix(r.Path, "/verify") {
+		return h.handleVerify(ctx, r.Body)
 	}
 
 	var req authRequest
-	if err := json.Unmarshal([]byte(rawBody), &req); err != nil {
-		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Request body must be valid JSON"), nil
+	if err := json.Unmarshal([]byte(r.Body), &req); err != nil {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Request body must be valid JSON")
 	}
 
-	if req.Email == "" || req.Password == "" {
-		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Both email and password are required."), nil
+	if strings.HasSuffix(r.Path, "/refresh") || req.GrantType == "refresh_token" {
+		return h.handleRefresh(ctx, req)
 	}
 
-	// Using email as the username for Cognito.
-	authParams := map[string]string{
-		"USERNAME": req.Email,
-		"PASSWORD": req.Password,
+	if strings.HasSuffix(r.Path, "/challenge") || (req.ChallengeName != "" && req.Session != "") {
+		return h.handleChallenge(ctx, req)
 	}
 
-	if h.cfg.clientSecret != "" {
-		secretHash, err := calcSecretHash(req.Email, h.cfg.clientID, h.cfg.clientSecret)
-		if err != nil {
-			h.logger.ErrorContext(ctx, "failed to calculate secret hash", "error", err)
-			return h.errorResponse(http.StatusInternalServerError, "server_error", "Failed to calculate client secret hash."), nil
-		}
-		authParams["SECRET_HASH"] = secretHash
+	switch {
+	case strings.HasSuffix(r.Path, "/signup"):
+		return h.handleSignUp(ctx, req)
+	case strings.HasSuffix(r.Path, "/confirm"):
+		return h.handleConfirm(ctx, req)
+	case strings.HasSuffix(r.Path, "/resend-code"):
+		return h.handleResendCode(ctx, req)
+	case strings.HasSuffix(r.Path, "/forgot-password"):
+		return h.handleForgotPassword(ctx, req)
+	case strings.HasSuffix(r.Path, "/reset-password"):
+		return h.handleResetPassword(ctx, req)
 	}
 
-	input := &cognitoidentityprovider.InitiateAuthInput{
-		AuthFlow:       types.AuthFlowTypeUserPasswordAuth,
-		AuthParameters: authParams,
-		ClientId:       aws.String(h.cfg.clientID),
+	if req.Email == "" || req.Password == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Both email and password are required.")
 	}
 
-	resp, err := h.cognito.InitiateAuth(ctx, input)
+	result, err := h.auth.Authenticate(ctx, req.Email, req.Password)
 	if err != nil {
-		return h.handleCognitoError(ctx, err), nil
+		return h.handleAuthError(ctx, err)
 	}
 
-	if resp.AuthenticationResult == nil {
-		challenge := string(resp.ChallengeName)
-		h.logger.InfoContext(ctx, "cognito returned a challenge", "challenge", challenge)
-		body, _ := json.Marshal(challengeResponse{
-			Error:     "challenge_required",
-			Message:   "Additional challenge required",
-			Challenge: &challenge,
-		})
-		return h.rawResponse(http.StatusForbidden, string(body)), nil
+	h.logger.InfoContext(ctx, "authentication successful", "email", req.Email)
+
+	body, _ := json.Marshal(authResponse{
+		Token:        result.Token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+		TokenType:    result.TokenType,
+	})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleRefresh exchanges a refresh token for new id/access tokens via the
+// provider's REFRESH_TOKEN_AUTH-equivalent flow.
+func (h *handler) handleRefresh(ctx context.Context, req authRequest) response {
+	if req.RefreshToken == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "refresh_token is required.")
+	}
+	if h.cfg.clientSecret != "" && req.Email == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email is required to refresh a token for this client.")
 	}
 
-	result := resp.AuthenticationResult
-	token := result.IdToken
-	if token == nil {
-		token = result.AccessToken
+	result, err := h.auth.RefreshToken(ctx, req.Email, req.RefreshToken)
+	if err != nil {
+		return h.handleAuthError(ctx, err)
 	}
 
-	h.logger.InfoContext(ctx, "authentication successful", "email", req.Email)
+	h.logger.InfoContext(ctx, "token refresh successful")
 
 	body, _ := json.Marshal(authResponse{
-		Token:     token,
-		ExpiresIn: &result.ExpiresIn,
+		Token:     result.Token,
+		ExpiresIn: result.ExpiresIn,
 		TokenType: result.TokenType,
 	})
-	return h.rawResponse(http.StatusOK, string(body)), nil
+	return h.rawResponse(http.StatusOK, string(body))
 }
 
-func (h *handler) handleCognitoError(ctx context.Context, err error) events.APIGatewayV2HTTPResponse {
-	var notAuth *types.NotAuthorizedException
-	if errors.As(err, &notAuth) {
-		return h.errorResponse(http.StatusUnauthorized, "invalid_credentials", "Invalid email or password.")
+// handleChallenge completes a login that paused on a provider challenge
+// (MFA, forced password change, ...) using the opaque Session handed back
+// from the initial Authenticate call.
+func (h *handler) handleChallenge(ctx context.Context, req authRequest) response {
+	if req.ChallengeName == "" || req.Session == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "challenge_name and session are required.")
+	}
+	if req.Email == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email is required to respond to a challenge.")
 	}
 
-	var userNotFound *types.UserNotFoundException
-	if errors.As(err, &userNotFound) {
-		return h.errorResponse(http.StatusNotFound, "user_not_found", "User does not exist.")
+	result, err := h.auth.RespondToChallenge(ctx, provider.ChallengeRequest{
+		Email:         req.Email,
+		ChallengeName: req.ChallengeName,
+		Session:       req.Session,
+		Responses:     req.Responses,
+	})
+	if err != nil {
+		return h.handleAuthError(ctx, err)
 	}
 
-	var notConfirmed *types.UserNotConfirmedException
-	if errors.As(err, &notConfirmed) {
-		return h.errorResponse(http.StatusForbidden, "user_not_confirmed", "User not confirmed.")
+	h.logger.InfoContext(ctx, "challenge response successful", "email", req.Email)
+
+	body, _ := json.Marshal(authResponse{
+		Token:        result.Token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+		TokenType:    result.TokenType,
+	})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleVerify checks an id/access token locally via the provider's
+// VerifyToken, for downstream services that just need to know a token
+// is valid without calling the identity provider on every request.
+func (h *handler) handleVerify(ctx context.Context, rawBody string) response {
+	var req verifyRequest
+	if err := json.Unmarshal([]byte(rawBody), &req); err != nil || req.Token == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "A token is required.")
+	}
+
+	claims, err := h.auth.VerifyToken(ctx, req.Token)
+	if err != nil {
+		h.logger.InfoContext(ctx, "token verification failed", "error", err)
+		return h.errorResponse(http.StatusUnauthorized, "invalid_token", "Token is invalid or expired.")
 	}
 
-	var passReset *types.PasswordResetRequiredException
-	if errors.As(err, &passReset) {
+	body, _ := json.Marshal(verifyResponse{
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		Username:  claims.Username,
+		TokenUse:  claims.TokenUse,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleSignUp registers a new account and sends out the provider's
+// verification code (e.g. a Cognito confirmation email).
+func (h *handler) handleSignUp(ctx context.Context, req authRequest) response {
+	if req.Email == "" || req.Password == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "Both email and password are required.")
+	}
+
+	result, err := h.auth.SignUp(ctx, provider.SignUpRequest{
+		Email:       req.Email,
+		Password:    req.Password,
+		Name:        req.Name,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		return h.handleAuthError(ctx, err)
+	}
+
+	h.logger.InfoContext(ctx, "sign up successful", "email", req.Email)
+
+	body, _ := json.Marshal(signUpResponse{
+		UserSub:       result.UserSub,
+		UserConfirmed: result.UserConfirmed,
+	})
+	return h.rawResponse(http.StatusCreated, string(body))
+}
+
+// handleConfirm completes sign-up by submitting the verification code
+// the provider sent the user.
+func (h *handler) handleConfirm(ctx context.Context, req authRequest) response {
+	if req.Email == "" || req.Code == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email and code are required.")
+	}
+
+	if err := h.auth.ConfirmSignUp(ctx, req.Email, req.Code); err != nil {
+		return h.handleAuthError(ctx, err)
+	}
+
+	h.logger.InfoContext(ctx, "sign up confirmed", "email", req.Email)
+
+	body, _ := json.Marshal(messageResponse{Message: "Account confirmed."})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleResendCode re-sends the sign-up verification code to a
+// not-yet-confirmed account.
+func (h *handler) handleResendCode(ctx context.Context, req authRequest) response {
+	if req.Email == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email is required.")
+	}
+
+	if err := h.auth.ResendConfirmationCode(ctx, req.Email); err != nil {
+		return h.handleAuthError(ctx, err)
+	}
+
+	body, _ := json.Marshal(messageResponse{Message: "Confirmation code resent."})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleForgotPassword kicks off the provider's password-reset flow,
+// sending the user a reset code.
+func (h *handler) handleForgotPassword(ctx context.Context, req authRequest) response {
+	if req.Email == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email is required.")
+	}
+
+	if err := h.auth.ForgotPassword(ctx, req.Email); err != nil {
+		return h.handleAuthError(ctx, err)
+	}
+
+	body, _ := json.Marshal(messageResponse{Message: "Password reset code sent."})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleResetPassword completes the password-reset flow using the code
+// sent by handleForgotPassword.
+func (h *handler) handleResetPassword(ctx context.Context, req authRequest) response {
+	if req.Email == "" || req.Code == "" || req.NewPassword == "" {
+		return h.errorResponse(http.StatusBadRequest, "invalid_request", "email, code, and new_password are required.")
+	}
+
+	if err := h.auth.ConfirmForgotPassword(ctx, req.Email, req.Code, req.NewPassword); err != nil {
+		return h.handleAuthError(ctx, err)
+	}
+
+	h.logger.InfoContext(ctx, "password reset successful", "email", req.Email)
+
+	body, _ := json.Marshal(messageResponse{Message: "Password reset successful."})
+	return h.rawResponse(http.StatusOK, string(body))
+}
+
+// handleAuthError maps a generic provider error to the service's JSON
+// error shape. Errors that don't match a known sentinel are treated as
+// opaque upstream failures.
+func (h *handler) handleAuthError(ctx context.Context, err error) response {
+	var challenge *provider.ErrChallengeRequired
+	if errors.As(err, &challenge) {
+		h.logger.InfoContext(ctx, "auth provider returned a challenge", "challenge", challenge.Name)
+		body, _ := json.Marshal(challengeResponse{
+			Error:     "challenge_required",
+			Message:   "Additional challenge required",
+			Challenge: &challenge.Name,
+			Session:   &challenge.Session,
+		})
+		return h.rawResponse(http.StatusForbidden, string(body))
+	}
+
+	switch {
+	case errors.Is(err, provider.ErrInvalidCredentials):
+		return h.errorResponse(http.StatusUnauthorized, "invalid_credentials", "Invalid email or password.")
+	case errors.Is(err, provider.ErrUserNotFound):
+		return h.errorResponse(http.StatusNotFound, "user_not_found", "User does not exist.")
+	case errors.Is(err, provider.ErrUserNotConfirmed):
+		return h.errorResponse(http.StatusForbidden, "user_not_confirmed", "User not confirmed.")
+	case errors.Is(err, provider.ErrPasswordResetRequired):
 		return h.errorResponse(http.StatusForbidden, "password_reset_required", "Password reset required.")
+	case errors.Is(err, provider.ErrRefreshTokenInvalid):
+		return h.errorResponse(http.StatusUnauthorized, "refresh_token_invalid", "Refresh token is invalid or expired.")
+	case errors.Is(err, provider.ErrUsernameExists):
+		return h.errorResponse(http.StatusConflict, "username_exists", "An account with this email already exists.")
+	case errors.Is(err, provider.ErrCodeMismatch):
+		return h.errorResponse(http.StatusBadRequest, "code_mismatch", "Verification code is incorrect.")
+	case errors.Is(err, provider.ErrExpiredCode):
+		return h.errorResponse(http.StatusBadRequest, "expired_code", "Verification code has expired.")
+	case errors.Is(err, provider.ErrInvalidPassword):
+		return h.errorResponse(http.StatusBadRequest, "invalid_password", "Password does not meet the required policy.")
+	case errors.Is(err, provider.ErrLimitExceeded):
+		return h.errorResponse(http.StatusTooManyRequests, "limit_exceeded", "Too many attempts. Please try again later.")
 	}
 
-	h.logger.ErrorContext(ctx, "unhandled cognito error", "error", err)
-	return h.errorResponse(http.StatusBadGateway, "upstream_error", "Cognito error: "+err.Error())
+	h.logger.ErrorContext(ctx, "unhandled auth provider error", "error", err)
+	return h.errorResponse(http.StatusBadGateway, "upstream_error", "Auth provider error: "+err.Error())
+}
+
+func (h *handler) errorResponse(statusCode int, errCode, message string) response {
+	body, _ := json.Marshal(errorResponse{Error: errCode, Message: message})
+	return h.rawResponse(statusCode, string(body))
+}
+
+func (h *handler) rawResponse(statusCode int, body string) response {
+	return response{StatusCode: statusCode, Body: body}
 }
 
 var defaultHeaders = map[string]string{
@@ -209,27 +491,119 @@ var defaultHeaders = map[string]string{
 	"Access-Control-Allow-Origin": "*",
 }
 
-func (h *handler) errorResponse(statusCode int, errCode, message string) events.APIGatewayV2HTTPResponse {
-	body, _ := json.Marshal(errorResponse{Error: errCode, Message: message})
-	return h.rawResponse(statusCode, string(body))
+// lambdaAdapter decodes an API Gateway HTTP API (payload format 2.0)
+// event into a transport-agnostic request, runs the core handler, and
+// re-encodes the result as the API Gateway response shape.
+func (h *handler) lambdaAdapter(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	ctx = logging.WithFields(ctx,
+		slog.String("request_id", event.RequestContext.RequestID),
+		slog.String("route", event.RawPath),
+		slog.String("source_ip", event.RequestContext.HTTP.SourceIP),
+		slog.String("user_agent", event.RequestContext.HTTP.UserAgent),
+	)
+
+	body := event.Body
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			resp := h.errorResponse(http.StatusBadRequest, "invalid_request", "Invalid base64 body")
+			return toAPIGatewayResponse(resp), nil
+		}
+		body = string(decoded)
+	}
+
+	resp := h.handle(ctx, request{Path: event.RawPath, Body: body})
+	return toAPIGatewayResponse(resp), nil
 }
 
-func (h *handler) rawResponse(statusCode int, body string) events.APIGatewayV2HTTPResponse {
+func toAPIGatewayResponse(resp response) events.APIGatewayV2HTTPResponse {
 	return events.APIGatewayV2HTTPResponse{
-		StatusCode:      statusCode,
+		StatusCode:      resp.StatusCode,
 		Headers:         defaultHeaders,
-		Body:            body,
+		Body:            resp.Body,
 		IsBase64Encoded: false,
 	}
 }
 
-// calcSecretHash computes: Base64( HMAC-SHA256( clientSecret, username + clientID ) )
-func calcSecretHash(username, clientID, clientSecret string) (string, error) {
-	mac := hmac.New(sha256.New, []byte(clientSecret))
-	if _, err := mac.Write([]byte(username + clientID)); err != nil {
-		return "", err
+// httpAdapter runs the core handler logic behind a plain net/http
+// listener, for local development and containerized deployment without
+// Lambda emulation.
+func (h *handler) httpAdapter(w http.ResponseWriter, r *http.Request) {
+	ctx := logging.WithFields(r.Context(),
+		slog.String("request_id", r.Header.Get("X-Request-Id")),
+		slog.String("route", r.URL.Path),
+		slog.String("source_ip", r.RemoteAddr),
+		slog.String("user_agent", r.UserAgent()),
+	)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		resp := h.errorResponse(http.StatusBadRequest, "invalid_request", "Failed to read request body.")
+		writeHTTPResponse(w, resp)
+		return
 	}
-	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+
+	resp := h.handle(ctx, request{Path: r.URL.Path, Body: string(body)})
+	writeHTTPResponse(w, resp)
+}
+
+func writeHTTPResponse(w http.ResponseWriter, resp response) {
+	for k, v := range defaultHeaders {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write([]byte(resp.Body))
+}
+
+// newMux wires every auth route to the same httpAdapter; the core
+// handler dispatches on the request path itself, so the mux only needs
+// to know which paths exist.
+func (h *handler) newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, path := range []string{
+		"/auth",
+		"/auth/refresh",
+		"/auth/challenge",
+		"/verify",
+		"/signup",
+		"/confirm",
+		"/resend-code",
+		"/forgot-password",
+		"/reset-password",
+	} {
+		mux.HandleFunc(path, h.httpAdapter)
+	}
+	return mux
+}
+
+// HandleAuthorizer adapts the provider's VerifyToken for use as an API
+// Gateway HTTP API (payload format 2.0) REQUEST authorizer: it extracts
+// the bearer token from the Authorization header, verifies it, and
+// returns a simple response (IsAuthorized plus the sub and username as
+// context). It does not return an IAM policy document, so the authorizer
+// must be configured for simple responses.
+func (h *handler) HandleAuthorizer(ctx context.Context, event events.APIGatewayV2CustomAuthorizerV2Request) (events.APIGatewayV2CustomAuthorizerSimpleResponse, error) {
+	token := strings.TrimPrefix(event.Headers["authorization"], "Bearer ")
+	if token == "" {
+		token = strings.TrimPrefix(event.Headers["Authorization"], "Bearer ")
+	}
+	if token == "" {
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{IsAuthorized: false}, nil
+	}
+
+	claims, err := h.auth.VerifyToken(ctx, token)
+	if err != nil {
+		h.logger.InfoContext(ctx, "authorizer denied request", "error", err)
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{IsAuthorized: false}, nil
+	}
+
+	return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+		IsAuthorized: true,
+		Context: map[string]interface{}{
+			"sub":      claims.Subject,
+			"username": claims.Username,
+		},
+	}, nil
 }
 
 func main() {
@@ -247,5 +621,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	lambda.Start(h.Handle)
+	if cfg.authorizerMode {
+		lambda.Start(h.HandleAuthorizer)
+		return
+	}
+
+	if cfg.runMode == "http" {
+		slog.Info("starting http server", "port", cfg.port)
+		if err := http.ListenAndServe(":"+cfg.port, h.newMux()); err != nil {
+			slog.Error("http server stopped", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	lambda.Start(h.lambdaAdapter)
 }
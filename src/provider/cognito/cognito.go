@@ -0,0 +1,404 @@
+// Package cognito implements provider.AuthProvider against AWS Cognito.
+package cognito
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/jwks"
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/provider"
+)
+
+// Config holds the Cognito app client and user pool this provider talks to.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	UserPoolID   string
+	Region       string
+}
+
+// Provider is the Cognito-backed provider.AuthProvider implementation.
+type Provider struct {
+	cfg      Config
+	client   *cognitoidentityprovider.Client
+	verifier *jwks.Verifier
+}
+
+// New builds a Cognito provider, loading AWS credentials from the default
+// chain and, when a user pool is configured, a JWKS verifier for VerifyToken.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	opts := []func(*config.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifier *jwks.Verifier
+	if cfg.UserPoolID != "" {
+		verifier = jwks.New(cfg.Region, cfg.UserPoolID, cfg.ClientID)
+	}
+
+	return &Provider{
+		cfg:      cfg,
+		client:   cognitoidentityprovider.NewFromConfig(awsCfg),
+		verifier: verifier,
+	}, nil
+}
+
+func (p *Provider) Authenticate(ctx context.Context, email, password string) (*provider.AuthResult, error) {
+	authParams := map[string]string{
+		"USERNAME": email,
+		"PASSWORD": password,
+	}
+	if err := p.addSecretHash(authParams, email); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.InitiateAuth(ctx, &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow:       types.AuthFlowTypeUserPasswordAuth,
+		AuthParameters: authParams,
+		ClientId:       aws.String(p.cfg.ClientID),
+	})
+	if err != nil {
+		return nil, p.mapError(err)
+	}
+
+	if resp.AuthenticationResult == nil {
+		return nil, &provider.ErrChallengeRequired{Name: string(resp.ChallengeName), Session: aws.ToString(resp.Session)}
+	}
+
+	return toAuthResult(resp.AuthenticationResult), nil
+}
+
+func (p *Provider) RefreshToken(ctx context.Context, email, refreshToken string) (*provider.AuthResult, error) {
+	authParams := map[string]string{
+		"REFRESH_TOKEN": refreshToken,
+	}
+	if p.cfg.ClientSecret != "" {
+		if err := p.addSecretHash(authParams, email); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := p.client.InitiateAuth(ctx, &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow:       types.AuthFlowTypeRefreshTokenAuth,
+		AuthParameters: authParams,
+		ClientId:       aws.String(p.cfg.ClientID),
+	})
+	if err != nil {
+		var notAuth *types.NotAuthorizedException
+		if errors.As(err, &notAuth) {
+			return nil, provider.ErrRefreshTokenInvalid
+		}
+		return nil, p.mapError(err)
+	}
+
+	if resp.AuthenticationResult == nil {
+		return nil, errors.New("cognito did not return an authentication result")
+	}
+
+	return toAuthResult(resp.AuthenticationResult), nil
+}
+
+// challengeResponseKey maps a Cognito challenge name to the key Cognito
+// expects the client's answer under in ChallengeResponses.
+var challengeResponseKey = map[types.ChallengeNameType]string{
+	types.ChallengeNameTypeSmsMfa:              "SMS_MFA_CODE",
+	types.ChallengeNameTypeSoftwareTokenMfa:    "SOFTWARE_TOKEN_MFA_CODE",
+	types.ChallengeNameTypeNewPasswordRequired: "NEW_PASSWORD",
+	types.ChallengeNameTypeSelectMfaType:       "ANSWER",
+}
+
+func (p *Provider) RespondToChallenge(ctx context.Context, req provider.ChallengeRequest) (*provider.AuthResult, error) {
+	challengeName := types.ChallengeNameType(req.ChallengeName)
+
+	challengeResponses := map[string]string{
+		"USERNAME": req.Email,
+	}
+	for k, v := range req.Responses {
+		challengeResponses[k] = v
+	}
+	if key, ok := challengeResponseKey[challengeName]; ok {
+		if answer, ok := req.Responses[key]; ok {
+			challengeResponses[key] = answer
+		}
+	}
+	if p.cfg.ClientSecret != "" {
+		if err := p.addSecretHash(challengeResponses, req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := p.client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName:      challengeName,
+		ChallengeResponses: challengeResponses,
+		ClientId:           aws.String(p.cfg.ClientID),
+		Session:            aws.String(req.Session),
+	})
+	if err != nil {
+		return nil, p.mapError(err)
+	}
+
+	if resp.AuthenticationResult == nil {
+		return nil, &provider.ErrChallengeRequired{Name: string(resp.ChallengeName), Session: aws.ToString(resp.Session)}
+	}
+
+	return toAuthResult(resp.AuthenticationResult), nil
+}
+
+func (p *Provider) VerifyToken(ctx context.Context, token string) (*jwks.Claims, error) {
+	if p.verifier == nil {
+		return nil, errors.New("token verification is not configured")
+	}
+
+	claims, err := p.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, provider.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (p *Provider) ListUsers(ctx context.Context) ([]provider.User, error) {
+	resp, err := p.client.ListUsers(ctx, &cognitoidentityprovider.ListUsersInput{
+		UserPoolId: aws.String(p.cfg.UserPoolID),
+	})
+	if err != nil {
+		return nil, p.mapError(err)
+	}
+
+	users := make([]provider.User, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		var email string
+		for _, attr := range u.Attributes {
+			if aws.ToString(attr.Name) == "email" {
+				email = aws.ToString(attr.Value)
+			}
+		}
+		users = append(users, provider.User{
+			Username: aws.ToString(u.Username),
+			Email:    email,
+			Enabled:  u.Enabled,
+			Status:   string(u.UserStatus),
+		})
+	}
+	return users, nil
+}
+
+func (p *Provider) SignUp(ctx context.Context, req provider.SignUpRequest) (*provider.SignUpResult, error) {
+	attrs := []types.AttributeType{
+		{Name: aws.String("email"), Value: aws.String(req.Email)},
+	}
+	if req.Name != "" {
+		attrs = append(attrs, types.AttributeType{Name: aws.String("name"), Value: aws.String(req.Name)})
+	}
+	if req.PhoneNumber != "" {
+		attrs = append(attrs, types.AttributeType{Name: aws.String("phone_number"), Value: aws.String(req.PhoneNumber)})
+	}
+
+	input := &cognitoidentityprovider.SignUpInput{
+		ClientId:       aws.String(p.cfg.ClientID),
+		Username:       aws.String(req.Email),
+		Password:       aws.String(req.Password),
+		UserAttributes: attrs,
+	}
+	secretHash, err := p.secretHash(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	input.SecretHash = secretHash
+
+	resp, err := p.client.SignUp(ctx, input)
+	if err != nil {
+		return nil, p.mapError(err)
+	}
+
+	return &provider.SignUpResult{
+		UserSub:       aws.ToString(resp.UserSub),
+		UserConfirmed: resp.UserConfirmed,
+	}, nil
+}
+
+func (p *Provider) ConfirmSignUp(ctx context.Context, email, code string) error {
+	secretHash, err := p.secretHash(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.ConfirmSignUp(ctx, &cognitoidentityprovider.ConfirmSignUpInput{
+		ClientId:         aws.String(p.cfg.ClientID),
+		Username:         aws.String(email),
+		ConfirmationCode: aws.String(code),
+		SecretHash:       secretHash,
+	})
+	if err != nil {
+		return p.mapError(err)
+	}
+	return nil
+}
+
+func (p *Provider) ResendConfirmationCode(ctx context.Context, email string) error {
+	secretHash, err := p.secretHash(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.ResendConfirmationCode(ctx, &cognitoidentityprovider.ResendConfirmationCodeInput{
+		ClientId:   aws.String(p.cfg.ClientID),
+		Username:   aws.String(email),
+		SecretHash: secretHash,
+	})
+	if err != nil {
+		return p.mapError(err)
+	}
+	return nil
+}
+
+func (p *Provider) ForgotPassword(ctx context.Context, email string) error {
+	secretHash, err := p.secretHash(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.ForgotPassword(ctx, &cognitoidentityprovider.ForgotPasswordInput{
+		ClientId:   aws.String(p.cfg.ClientID),
+		Username:   aws.String(email),
+		SecretHash: secretHash,
+	})
+	if err != nil {
+		return p.mapError(err)
+	}
+	return nil
+}
+
+func (p *Provider) ConfirmForgotPassword(ctx context.Context, email, code, newPassword string) error {
+	secretHash, err := p.secretHash(email)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.ConfirmForgotPassword(ctx, &cognitoidentityprovider.ConfirmForgotPasswordInput{
+		ClientId:         aws.String(p.cfg.ClientID),
+		Username:         aws.String(email),
+		ConfirmationCode: aws.String(code),
+		Password:         aws.String(newPassword),
+		SecretHash:       secretHash,
+	})
+	if err != nil {
+		return p.mapError(err)
+	}
+	return nil
+}
+
+// addSecretHash computes and sets SECRET_HASH on params when this app
+// client was configured with a client secret; it's a no-op otherwise.
+func (p *Provider) addSecretHash(params map[string]string, username string) error {
+	if p.cfg.ClientSecret == "" {
+		return nil
+	}
+	secretHash, err := calcSecretHash(username, p.cfg.ClientID, p.cfg.ClientSecret)
+	if err != nil {
+		return err
+	}
+	params["SECRET_HASH"] = secretHash
+	return nil
+}
+
+// secretHash computes the SECRET_HASH value for username as a pointer,
+// for the SDK inputs (SignUp, ConfirmSignUp, ...) that take it as a
+// top-level field rather than an AuthParameters entry. Returns nil when
+// this app client was configured without a client secret.
+func (p *Provider) secretHash(username string) (*string, error) {
+	if p.cfg.ClientSecret == "" {
+		return nil, nil
+	}
+	secretHash, err := calcSecretHash(username, p.cfg.ClientID, p.cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	return aws.String(secretHash), nil
+}
+
+// calcSecretHash computes: Base64( HMAC-SHA256( clientSecret, username + clientID ) )
+func calcSecretHash(username, clientID, clientSecret string) (string, error) {
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	if _, err := mac.Write([]byte(username + clientID)); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func toAuthResult(result *types.AuthenticationResultType) *provider.AuthResult {
+	token := result.IdToken
+	if token == nil {
+		token = result.AccessToken
+	}
+	return &provider.AuthResult{
+		Token:        token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    &result.ExpiresIn,
+		TokenType:    result.TokenType,
+	}
+}
+
+// mapError translates a Cognito SDK error into the generic errors callers
+// of provider.AuthProvider understand.
+func (p *Provider) mapError(err error) error {
+	var notAuth *types.NotAuthorizedException
+	if errors.As(err, &notAuth) {
+		return provider.ErrInvalidCredentials
+	}
+
+	var userNotFound *types.UserNotFoundException
+	if errors.As(err, &userNotFound) {
+		return provider.ErrUserNotFound
+	}
+
+	var notConfirmed *types.UserNotConfirmedException
+	if errors.As(err, &notConfirmed) {
+		return provider.ErrUserNotConfirmed
+	}
+
+	var passReset *types.PasswordResetRequiredException
+	if errors.As(err, &passReset) {
+		return provider.ErrPasswordResetRequired
+	}
+
+	var usernameExists *types.UsernameExistsException
+	if errors.As(err, &usernameExists) {
+		return provider.ErrUsernameExists
+	}
+
+	var codeMismatch *types.CodeMismatchException
+	if errors.As(err, &codeMismatch) {
+		return provider.ErrCodeMismatch
+	}
+
+	var expiredCode *types.ExpiredCodeException
+	if errors.As(err, &expiredCode) {
+		return provider.ErrExpiredCode
+	}
+
+	var invalidPassword *types.InvalidPasswordException
+	if errors.As(err, &invalidPassword) {
+		return provider.ErrInvalidPassword
+	}
+
+	var limitExceeded *types.LimitExceededException
+	if errors.As(err, &limitExceeded) {
+		return provider.ErrLimitExceeded
+	}
+
+	return err
+}
@@ -0,0 +1,99 @@
+// Package provider defines the identity-provider abstraction the auth
+// service is built against, so the HTTP/Lambda layer never has to know
+// whether it's talking to Cognito, a local stub, or anything else.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FIAP-11soat-grupo-21/hackathon-auth-microservice/src/jwks"
+)
+
+// AuthResult carries the tokens returned by a successful authentication,
+// refresh, or challenge response.
+type AuthResult struct {
+	Token        *string
+	RefreshToken *string
+	ExpiresIn    *int32
+	TokenType    *string
+}
+
+// ChallengeRequest describes a client's answer to a provider challenge
+// (MFA, forced password change, ...) returned from Authenticate.
+type ChallengeRequest struct {
+	Email         string
+	ChallengeName string
+	Session       string
+	Responses     map[string]string
+}
+
+// User is a minimal, provider-agnostic view of an account, returned by
+// ListUsers.
+type User struct {
+	Username string
+	Email    string
+	Enabled  bool
+	Status   string
+}
+
+// SignUpRequest carries the attributes needed to register a new account.
+type SignUpRequest struct {
+	Email       string
+	Password    string
+	Name        string
+	PhoneNumber string
+}
+
+// SignUpResult reports the outcome of a successful SignUp call.
+type SignUpResult struct {
+	UserSub       string
+	UserConfirmed bool
+}
+
+// AuthProvider is implemented by every identity backend the service can
+// run against. Implementations translate their own SDK-specific errors
+// into the sentinel errors below where one applies; errors with no
+// matching sentinel are returned as they are.
+type AuthProvider interface {
+	Authenticate(ctx context.Context, email, password string) (*AuthResult, error)
+	RefreshToken(ctx context.Context, email, refreshToken string) (*AuthResult, error)
+	RespondToChallenge(ctx context.Context, req ChallengeRequest) (*AuthResult, error)
+	VerifyToken(ctx context.Context, token string) (*jwks.Claims, error)
+	ListUsers(ctx context.Context) ([]User, error)
+
+	SignUp(ctx context.Context, req SignUpRequest) (*SignUpResult, error)
+	ConfirmSignUp(ctx context.Context, email, code string) error
+	ResendConfirmationCode(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ConfirmForgotPassword(ctx context.Context, email, code, newPassword string) error
+}
+
+// Sentinel errors returned by every AuthProvider implementation. The
+// HTTP/Lambda layer maps these to the service's JSON error shape and
+// never needs to know which backend produced them.
+var (
+	ErrInvalidCredentials    = errors.New("invalid_credentials")
+	ErrUserNotFound          = errors.New("user_not_found")
+	ErrUserNotConfirmed      = errors.New("user_not_confirmed")
+	ErrPasswordResetRequired = errors.New("password_reset_required")
+	ErrRefreshTokenInvalid   = errors.New("refresh_token_invalid")
+	ErrInvalidToken          = errors.New("invalid_token")
+	ErrUsernameExists        = errors.New("username_exists")
+	ErrCodeMismatch          = errors.New("code_mismatch")
+	ErrExpiredCode           = errors.New("expired_code")
+	ErrInvalidPassword       = errors.New("invalid_password")
+	ErrLimitExceeded         = errors.New("limit_exceeded")
+)
+
+// ErrChallengeRequired signals that authentication paused on a challenge
+// that the caller must complete via RespondToChallenge.
+type ErrChallengeRequired struct {
+	Name    string
+	Session string
+}
+
+func (e *ErrChallengeRequired) Error() string {
+	return fmt.Sprintf("challenge required: %s", e.Name)
+}